@@ -1,529 +1,1476 @@
-package main
-
-import (
-	"bytes"
-	"fmt"
-	"go/ast"
-	"go/format"
-	"go/parser"
-	"go/token"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
-	"text/template"
-
-	"github.com/spf13/pflag"
-)
-
-var (
-	srcDir           = pflag.StringP("src", "s", "", "源目录路径")
-	dstDir           = pflag.StringP("dst", "d", "", "目标目录路径")
-	excludeStr       = pflag.StringP("exclude", "e", "", "排除的文件或目录，多个用逗号分隔")
-	stPattern        = pflag.StringP("stPattern", "p", "^.+Dao$", "结构体名称匹配的正则表达式，默认匹配以Dao结尾的结构体")
-	interfacePrefix  = pflag.StringP("prefix", "f", "I", "接口前缀，默认是 I")
-	generateRegister = pflag.BoolP("generateRegister", "r", false, "是否生成实体变量和注册函数，默认不生成")
-	generateMock     = pflag.BoolP("generateMock", "m", false, "是否生成mockgen指令，默认不生成")
-	mockPath         = pflag.StringP("mockPath", "k", "../mocks", "mock文件的生成路径，默认是 ../mocks")
-)
-
-// 存储导入包信息
-type ImportInfo struct {
-	Name string
-	Path string
-}
-
-// 存储结构体方法信息
-type MethodInfo struct {
-	Name       string
-	Params     string
-	Results    string
-	ParamNames string
-	UsedTypes  map[string]bool // 用于跟踪方法中使用的类型
-	Comment    string          // 方法注释
-}
-
-// 存储结构体信息
-type StructInfo struct {
-	Name             string
-	CapitalizedName  string // 首字母大写的结构体名称
-	InterfaceName    string
-	Methods          []MethodInfo
-	Imports          []ImportInfo
-	PackageName      string
-	UsedImports      map[string]bool // 用于跟踪接口中使用的导入
-	GenerateRegister bool            // 是否生成注册函数的标志
-	GenerateMock     bool            // 是否生成mockgen指令的标志
-	MockPath         string          // mock文件的生成路径
-	TargetFileName   string          // 目标文件名
-}
-
-func main() {
-	pflag.Parse()
-
-	if *srcDir == "" || *dstDir == "" {
-		fmt.Println("请指定源目录和目标目录")
-		pflag.Usage()
-		return
-	}
-
-	// 编译结构体名称的正则表达式
-	structPattern, err := regexp.Compile(*stPattern)
-	if err != nil {
-		fmt.Printf("无效的正则表达式 '%s': %v\n", *stPattern, err)
-		return
-	}
-
-	// 解析排除列表
-	excludeList := []string{}
-	if *excludeStr != "" {
-		excludeList = strings.Split(*excludeStr, ",")
-	}
-
-	// 确保目标目录存在
-	if err := os.MkdirAll(*dstDir, 0755); err != nil {
-		fmt.Printf("创建目标目录失败: %v\n", err)
-		return
-	}
-
-	// 处理源目录
-	if err := processDirectory(*srcDir, *dstDir, excludeList, structPattern); err != nil {
-		fmt.Printf("处理目录失败: %v\n", err)
-	}
-}
-
-// 处理目录
-func processDirectory(srcDir, dstDir string, excludeList []string, structPattern *regexp.Regexp) error {
-	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(srcDir, path)
-		if err != nil {
-			return err
-		}
-
-		// 检查是否在排除列表中
-		for _, exclude := range excludeList {
-			// 检查是否匹配文件名
-			if matched, _ := filepath.Match(exclude, filepath.Base(path)); matched {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-
-			// 检查是否匹配相对路径
-			if matched, _ := filepath.Match(exclude, relPath); matched {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-
-			// 检查是否匹配目录前缀
-			if info.IsDir() && strings.HasPrefix(relPath, exclude) {
-				return filepath.SkipDir
-			}
-		}
-
-		// 处理Go文件
-		if !info.IsDir() && strings.HasSuffix(path, ".go") {
-			return processGoFile(path, srcDir, dstDir, structPattern)
-		}
-
-		return nil
-	})
-}
-
-// 处理Go文件
-func processGoFile(filePath, srcDir, dstDir string, structPattern *regexp.Regexp) error {
-	// 解析Go文件
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
-	if err != nil {
-		return fmt.Errorf("解析文件 %s 失败: %v", filePath, err)
-	}
-
-	// 获取包名
-	//packageName := node.Name.Name
-
-	// 收集导入信息
-	imports := []ImportInfo{}
-	for _, imp := range node.Imports {
-		var name string
-		if imp.Name != nil {
-			name = imp.Name.Name
-		}
-		path := strings.Trim(imp.Path.Value, "\"")
-		imports = append(imports, ImportInfo{Name: name, Path: path})
-	}
-
-	// 查找结构体并生成接口
-	for _, decl := range node.Decls {
-		genDecl, ok := decl.(*ast.GenDecl)
-		if !ok || genDecl.Tok != token.TYPE {
-			continue
-		}
-
-		for _, spec := range genDecl.Specs {
-			typeSpec, ok := spec.(*ast.TypeSpec)
-			if !ok {
-				continue
-			}
-
-			// 确认是结构体
-			_, isStruct := typeSpec.Type.(*ast.StructType)
-			if !isStruct {
-				continue
-			}
-
-			structName := typeSpec.Name.Name
-
-			// 检查结构体名称是否匹配模式
-			if !structPattern.MatchString(structName) {
-				continue
-			}
-
-			// 收集结构体方法和使用的类型
-			methods, usedTypes := findStructMethods(node, structName)
-
-			if len(methods) > 0 {
-				// 确定使用的导入
-				usedImports := findUsedImports(imports, usedTypes)
-
-				// 创建接口信息
-				interfaceName := *interfacePrefix + strings.ToUpper(structName[:1]) + structName[1:]
-
-				// 生成首字母大写的结构体名称
-				capitalizedName := strings.ToUpper(structName[:1]) + structName[1:]
-
-				// 生成目标文件名
-				targetFileName := filepath.Base(filePath)
-
-				structInfo := StructInfo{
-					Name:             structName,
-					CapitalizedName:  capitalizedName,
-					InterfaceName:    interfaceName,
-					Methods:          methods,
-					Imports:          imports,
-					PackageName:      filepath.Base(dstDir), // 使用目标目录名称作为包名
-					UsedImports:      usedImports,
-					GenerateRegister: *generateRegister,
-					GenerateMock:     *generateMock,
-					MockPath:         *mockPath,
-					TargetFileName:   targetFileName,
-				}
-
-				// 生成接口文件
-				if err := generateInterfaceFile(structInfo, filePath, srcDir, dstDir); err != nil {
-					return fmt.Errorf("生成接口文件失败: %v", err)
-				}
-			}
-		}
-	}
-
-	return nil
-}
-
-// 查找结构体的方法并跟踪使用的类型
-func findStructMethods(node *ast.File, structName string) ([]MethodInfo, map[string]bool) {
-	methods := []MethodInfo{}
-	allUsedTypes := make(map[string]bool)
-
-	for _, decl := range node.Decls {
-		funcDecl, ok := decl.(*ast.FuncDecl)
-		if !ok || funcDecl.Recv == nil {
-			continue
-		}
-
-		// 检查接收者类型
-		if len(funcDecl.Recv.List) == 0 {
-			continue
-		}
-
-		receiver := funcDecl.Recv.List[0].Type
-		var receiverName string
-
-		// 处理指针接收者
-		if starExpr, ok := receiver.(*ast.StarExpr); ok {
-			if ident, ok := starExpr.X.(*ast.Ident); ok {
-				receiverName = ident.Name
-			}
-		} else if ident, ok := receiver.(*ast.Ident); ok {
-			receiverName = ident.Name
-		}
-
-		if receiverName != structName {
-			continue
-		}
-
-		// 收集方法信息
-		methodName := funcDecl.Name.Name
-
-		// 跟踪方法中使用的类型
-		usedTypes := make(map[string]bool)
-
-		// 获取参数
-		params := formatFieldList(funcDecl.Type.Params, usedTypes)
-
-		// 获取返回值
-		results := formatFieldList(funcDecl.Type.Results, usedTypes)
-
-		// 获取参数名
-		paramNames := formatParamNames(funcDecl.Type.Params)
-
-		// 提取注释
-		comment := ""
-		if funcDecl.Doc != nil && len(funcDecl.Doc.List) > 0 {
-			comment = strings.TrimSpace(funcDecl.Doc.Text())
-		}
-
-		methods = append(methods, MethodInfo{
-			Name:       methodName,
-			Params:     params,
-			Results:    results,
-			ParamNames: paramNames,
-			UsedTypes:  usedTypes,
-			Comment:    comment,
-		})
-
-		// 合并所有方法中使用的类型
-		for t := range usedTypes {
-			allUsedTypes[t] = true
-		}
-	}
-
-	return methods, allUsedTypes
-}
-
-// 格式化字段列表并跟踪使用的类型
-func formatFieldList(fieldList *ast.FieldList, usedTypes map[string]bool) string {
-	if fieldList == nil || len(fieldList.List) == 0 {
-		return ""
-	}
-
-	var result []string
-	for _, field := range fieldList.List {
-		typeExpr := formatExpr(field.Type)
-
-		// 跟踪使用的类型
-		collectUsedTypes(field.Type, usedTypes)
-
-		if len(field.Names) == 0 {
-			result = append(result, typeExpr)
-		} else {
-			for _, name := range field.Names {
-				result = append(result, fmt.Sprintf("%s %s", name.Name, typeExpr))
-			}
-		}
-	}
-
-	return strings.Join(result, ", ")
-}
-
-// 收集表达式中使用的类型
-func collectUsedTypes(expr ast.Expr, usedTypes map[string]bool) {
-	switch t := expr.(type) {
-	case *ast.Ident:
-		if t.Name != "string" && t.Name != "int" && t.Name != "bool" && t.Name != "error" &&
-			t.Name != "uint" && t.Name != "int64" && t.Name != "uint64" && t.Name != "float64" &&
-			t.Name != "byte" && t.Name != "rune" {
-			usedTypes[t.Name] = true
-		}
-	case *ast.SelectorExpr:
-		if x, ok := t.X.(*ast.Ident); ok {
-			usedTypes[x.Name+"."+t.Sel.Name] = true
-		}
-	case *ast.StarExpr:
-		collectUsedTypes(t.X, usedTypes)
-	case *ast.ArrayType:
-		collectUsedTypes(t.Elt, usedTypes)
-	case *ast.MapType:
-		collectUsedTypes(t.Key, usedTypes)
-		collectUsedTypes(t.Value, usedTypes)
-	case *ast.InterfaceType:
-		// 标记为使用了 interface
-		usedTypes["interface{}"] = true
-	}
-}
-
-// 查找接口中使用的导入
-func findUsedImports(imports []ImportInfo, usedTypes map[string]bool) map[string]bool {
-	usedImports := make(map[string]bool)
-
-	for _, imp := range imports {
-		// 获取包的最后一部分作为包名
-		pkgName := imp.Name
-		if pkgName == "" {
-			parts := strings.Split(imp.Path, "/")
-			pkgName = parts[len(parts)-1]
-		}
-
-		// 检查是否有使用这个包的类型
-		for typeName := range usedTypes {
-			if strings.HasPrefix(typeName, pkgName+".") {
-				usedImports[imp.Path] = true
-				break
-			}
-		}
-	}
-
-	return usedImports
-}
-
-// 格式化参数名
-func formatParamNames(fieldList *ast.FieldList) string {
-	if fieldList == nil || len(fieldList.List) == 0 {
-		return ""
-	}
-
-	var result []string
-	for _, field := range fieldList.List {
-		if len(field.Names) == 0 {
-			result = append(result, "_")
-		} else {
-			for _, name := range field.Names {
-				result = append(result, name.Name)
-			}
-		}
-	}
-
-	return strings.Join(result, ", ")
-}
-
-// 格式化表达式
-func formatExpr(expr ast.Expr) string {
-	var buf bytes.Buffer
-	printer := token.NewFileSet()
-	format.Node(&buf, printer, expr)
-	return buf.String()
-}
-
-// 生成接口文件
-func generateInterfaceFile(info StructInfo, srcFilePath, srcDir, dstDir string) error {
-	// 计算相对路径
-	relPath, err := filepath.Rel(srcDir, srcFilePath)
-	if err != nil {
-		return err
-	}
-
-	// 生成目标目录
-	targetDir := filepath.Join(dstDir, filepath.Dir(relPath))
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return err
-	}
-
-	// 使用目标目录名称作为包名
-	targetPackageName := filepath.Base(targetDir)
-
-	// 使用源文件的基本名称作为生成文件名
-	targetFilePath := filepath.Join(targetDir, info.TargetFileName)
-
-	// 使用模板生成接口文件
-	tmpl, err := template.New("interface").Parse(`package {{.TargetPackageName}}
-
-import (
-	{{range .Imports}}{{if .Used}}{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
-	{{end}}{{end}}
-)
-
-// {{.InterfaceName}} 是 {{.Name}} 的接口定义
-{{if .GenerateMock}}//go:generate mockgen -source={{.TargetFileName}} -destination={{.MockPath}}/{{.TargetFileName}} -package=mocks{{end}}
-type {{.InterfaceName}} interface {
-	{{range .Methods}}{{if .Comment}}// {{.Comment}}{{end}}
-	{{.Name}}({{.Params}}) {{if .Results}}({{.Results}}){{end}}
-	{{end}}
-}
-
-{{if .GenerateRegister}}var (
-	local{{.InterfaceName}} {{.InterfaceName}}
-)
-
-func {{.CapitalizedName}}() {{.InterfaceName}} {
-	if local{{.InterfaceName}} == nil {
-		panic("implement not found for interface {{.InterfaceName}}, forgot register?")
-	}
-	return local{{.InterfaceName}}
-}
-
-func Register{{.CapitalizedName}}(i {{.InterfaceName}}) {
-	local{{.InterfaceName}} = i
-}{{end}}
-`)
-	if err != nil {
-		return err
-	}
-
-	// 准备模板数据
-	type TemplateImport struct {
-		Name string
-		Path string
-		Used bool
-	}
-
-	templateImports := []TemplateImport{}
-	for _, imp := range info.Imports {
-		used := info.UsedImports[imp.Path]
-		templateImports = append(templateImports, TemplateImport{
-			Name: imp.Name,
-			Path: imp.Path,
-			Used: used,
-		})
-	}
-
-	// 检查是否有使用的导入
-	hasImports := false
-	for _, imp := range templateImports {
-		if imp.Used {
-			hasImports = true
-			break
-		}
-	}
-
-	templateData := struct {
-		StructInfo
-		Imports           []TemplateImport
-		HasImports        bool
-		TargetPackageName string
-	}{
-		StructInfo:        info,
-		Imports:           templateImports,
-		HasImports:        hasImports,
-		TargetPackageName: targetPackageName, // 使用目标目录名称作为包名
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, templateData); err != nil {
-		return err
-	}
-
-	// 格式化代码
-	formattedCode, err := format.Source(buf.Bytes())
-	if err != nil {
-		return fmt.Errorf("格式化代码失败: %v\n%s", err, buf.String())
-	}
-
-	// 写入文件
-	if err := ioutil.WriteFile(targetFilePath, formattedCode, 0644); err != nil {
-		return err
-	}
-
-	fmt.Printf("生成接口文件: %s\n", targetFilePath)
-	return nil
-}
-
-// 将大驼峰命名转换为蛇形命名
-func toSnakeCase(s string) string {
-	var result string
-	for i, r := range s {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			result += "_"
-		}
-		result += strings.ToLower(string(r))
-	}
-	return result
-}
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	srcDir               = pflag.StringP("src", "s", "", "源目录路径")
+	dstDir               = pflag.StringP("dst", "d", "", "目标目录路径")
+	excludeStr           = pflag.StringP("exclude", "e", "", "排除的文件或目录，多个用逗号分隔")
+	stPattern            = pflag.StringP("stPattern", "p", "^.+Dao$", "结构体名称匹配的正则表达式，默认匹配以Dao结尾的结构体")
+	interfacePrefix      = pflag.StringP("prefix", "f", "I", "接口前缀，默认是 I")
+	generateRegister     = pflag.BoolP("generateRegister", "r", false, "是否生成实体变量和注册函数，默认不生成")
+	generateMock         = pflag.BoolP("generateMock", "m", false, "是否生成mock（mockgen 生成go:generate指令，moq/testify 直接生成mock文件，见 --mockStyle），默认不生成")
+	mockPath             = pflag.StringP("mockPath", "k", "../mocks", "mock文件的生成路径，默认是 ../mocks")
+	mockStyle            = pflag.String("mockStyle", "mockgen", "mock生成方式：mockgen|moq|testify，默认 mockgen（只生成go:generate指令）")
+	generateInitRegistry = pflag.BoolP("generateInitRegistry", "i", false, "是否为每个目标包生成 init 注册文件，依赖 --generateRegister")
+)
+
+// 存储导入包信息
+type ImportInfo struct {
+	Name string
+	Path string
+}
+
+// Param 描述一个参数或返回值：Name 用于生成 mock 代码中的字段名/变量名，
+// 当原始签名未命名时会填充占位名（如 arg0）；Type 是 go/types 解析后的类型字符串。
+// IsVariadic 标记该参数是否是 ...T 形式的可变参数——Type 里保留 "..." 是为了拼方法签名，
+// 但调用记录结构体的字段类型、转发调用时的实参都必须按切片处理，不能原样搬 "...T"
+type Param struct {
+	Name       string
+	Type       string
+	IsVariadic bool
+}
+
+// 存储结构体方法信息
+type MethodInfo struct {
+	Name       string
+	Params     string
+	Results    string
+	ParamNames string
+	ParamList  []Param // 参数名称与类型，供 moq/testify mock 生成使用
+	ResultList []Param // 返回值类型，供 testify mock 生成使用（Name 无意义）
+	Comment    string  // 方法注释
+}
+
+// 存储结构体信息
+type StructInfo struct {
+	Name              string
+	CapitalizedName   string // 首字母大写的结构体名称
+	InterfaceName     string
+	Methods           []MethodInfo
+	Imports           []ImportInfo
+	PackageImportPath string          // 生成的接口包的真实导入路径，来自最近的 go.mod；找不到 go.mod 时为空
+	BuildConstraints  []string        // 源文件上的构建约束（//go:build、// +build），原样复制到生成文件
+	UsedImports       map[string]bool // 用于跟踪接口中使用的导入
+	GenerateRegister  bool            // 是否生成注册函数的标志
+	GenerateMock      bool            // 是否生成mock代码的标志
+	MockStyle         string          // mock生成方式：mockgen|moq|testify
+	MockPath          string          // mock文件的生成路径
+	TargetFileName    string          // 目标文件名
+}
+
+func main() {
+	pflag.Parse()
+
+	if *srcDir == "" || *dstDir == "" {
+		fmt.Println("请指定源目录和目标目录")
+		pflag.Usage()
+		return
+	}
+
+	// 编译结构体名称的正则表达式
+	structPattern, err := regexp.Compile(*stPattern)
+	if err != nil {
+		fmt.Printf("无效的正则表达式 '%s': %v\n", *stPattern, err)
+		return
+	}
+
+	// 解析排除列表
+	excludeList := []string{}
+	if *excludeStr != "" {
+		excludeList = strings.Split(*excludeStr, ",")
+	}
+
+	// 确保目标目录存在
+	if err := os.MkdirAll(*dstDir, 0755); err != nil {
+		fmt.Printf("创建目标目录失败: %v\n", err)
+		return
+	}
+
+	// 处理源目录
+	if err := processDirectory(*srcDir, *dstDir, excludeList, structPattern); err != nil {
+		fmt.Printf("处理目录失败: %v\n", err)
+	}
+}
+
+// 处理目录：先按原有的排除规则收集所有包含 .go 文件的目录，
+// 再逐个目录交给 go/packages 加载，以获得跨文件、跨模块的类型信息
+func processDirectory(srcDir, dstDir string, excludeList []string, structPattern *regexp.Regexp) error {
+	// packages.Load 返回的文件路径是绝对路径，统一转换 srcDir 避免后续 filepath.Rel 失败
+	srcDir, err := filepath.Abs(srcDir)
+	if err != nil {
+		return err
+	}
+
+	// 合并源目录根部 .gitignore 里的规则，这样 -s 指到仓库根目录时
+	// 不用再手动把 vendor/、bin/、testdata/ 这些目录一一列进 --exclude
+	excludeList = append(excludeList, parseGitignore(srcDir)...)
+
+	pkgDirs := map[string]bool{}
+
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// 计算相对路径
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+
+		// 检查是否在排除列表中
+		for _, exclude := range excludeList {
+			// 检查是否匹配文件名
+			if matched, _ := filepath.Match(exclude, filepath.Base(path)); matched {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// 检查是否匹配相对路径
+			if matched, _ := filepath.Match(exclude, relPath); matched {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// 检查是否匹配目录前缀：必须按路径分段比较，不能用裸字符串前缀匹配——
+			// 否则像 "bin" 这样再普通不过的 .gitignore 规则会把 "bindings" 这样
+			// 仅仅是名字前缀相同的兄弟目录也一并排除掉
+			if info.IsDir() && (relPath == exclude || strings.HasPrefix(relPath, exclude+string(filepath.Separator))) {
+				return filepath.SkipDir
+			}
+		}
+
+		if !info.IsDir() && strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			pkgDirs[filepath.Dir(path)] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var registry []registryEntry
+
+	for dir := range pkgDirs {
+		// 一个目录加载/生成失败（无法解析的导入、缺失的间接依赖、平台专属的构建标签
+		// 文件等）不应该让已经扫描到的其他目录全部陪葬——记录下来继续处理剩下的目录
+		if err := processPackageDir(dir, srcDir, dstDir, structPattern, &registry); err != nil {
+			fmt.Printf("处理目录 %s 失败，已跳过: %v\n", dir, err)
+		}
+	}
+
+	if *generateInitRegistry {
+		if err := generateInitRegistryFiles(registry); err != nil {
+			return fmt.Errorf("生成 init 注册文件失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// parseGitignore 读取源目录根部的 .gitignore，把其中的文件通配符和目录前缀规则
+// 统一转换成 processDirectory 里 --exclude 已有的匹配格式（按文件名/相对路径做 Match，
+// 按相对路径做目录前缀匹配），不识别的否定规则（! 开头）直接忽略
+func parseGitignore(srcDir string) []string {
+	data, err := os.ReadFile(filepath.Join(srcDir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		// 去掉锚定根目录的前导 "/" 和标记目录的末尾 "/"，
+		// 剩下的文件通配符和目录前缀都交给已有的 Match/HasPrefix 逻辑处理
+		line = strings.TrimPrefix(line, "/")
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns
+}
+
+// findModule 从 dir 开始逐级向上查找最近的 go.mod，返回其声明的模块路径和所在目录（模块根）
+func findModule(dir string) (modulePath string, moduleRoot string, ok bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", false
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			if modPath := modfile.ModulePath(data); modPath != "" {
+				return modPath, dir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
+
+// packageImportPathFor 结合最近的 go.mod 计算 targetDir 对应的真实导入路径，
+// 找不到 go.mod 时返回空字符串（例如目标目录不在任何模块内，或在沙箱里没有 go.mod）
+func packageImportPathFor(targetDir string) string {
+	modulePath, moduleRoot, ok := findModule(targetDir)
+	if !ok {
+		return ""
+	}
+
+	targetDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return ""
+	}
+
+	rel, err := filepath.Rel(moduleRoot, targetDir)
+	if err != nil || rel == "." {
+		return modulePath
+	}
+
+	return path.Join(modulePath, filepath.ToSlash(rel))
+}
+
+// targetDirFor 计算某个源文件在目标目录下对应的输出目录，和源文件相对源目录保持同样的层级，
+// 这样嵌套的源目录结构在目标目录下也能原样保留
+func targetDirFor(srcDir, dstDir, srcFilePath string) (string, error) {
+	relPath, err := filepath.Rel(srcDir, srcFilePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dstDir, filepath.Dir(relPath)), nil
+}
+
+// extractBuildConstraints 单独用 go/parser 重新解析源文件，取出包声明之前的构建约束
+// 注释（//go:build 及旧式 // +build），原样复制到生成的接口文件，保证生成文件在
+// 同样的构建条件下才会被编译
+func extractBuildConstraints(srcFilePath string) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, srcFilePath, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var constraints []string
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			text := c.Text
+			if strings.HasPrefix(text, "//go:build") || strings.HasPrefix(text, "// +build") || strings.HasPrefix(text, "//+build") {
+				constraints = append(constraints, text)
+			}
+		}
+	}
+
+	return constraints, nil
+}
+
+// 加载单个目录下的包并处理其中的 Go 文件。
+// 使用 packages.Load 而不是 parser.ParseFile，使得生成器能够看到
+// 同一个包内其他文件定义的方法、内嵌类型，并准确解析导入的类型别名。
+func processPackageDir(dir, srcDir, dstDir string, structPattern *regexp.Regexp, registry *[]registryEntry) error {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes |
+			packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir:   dir,
+		Fset:  token.NewFileSet(),
+		Tests: false,
+	}
+
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return fmt.Errorf("加载目录 %s 失败: %v", dir, err)
+	}
+
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			// 解析/类型检查有错误（缺失的导入、不存在的依赖等）意味着 go/types 给出的
+			// 类型信息不完整甚至无效，继续生成只会产出 "invalid type" 这样的垃圾代码，
+			// 所以这个包直接跳过，不影响同一目录下其他已经或将要处理的包
+			for _, pkgErr := range pkg.Errors {
+				fmt.Printf("包 %s 存在解析或类型检查错误，已跳过: %v\n", dir, pkgErr)
+			}
+			continue
+		}
+
+		if err := processPackage(pkg, srcDir, dstDir, structPattern, registry); err != nil {
+			fmt.Printf("处理包 %s 失败，已跳过: %v\n", dir, err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// structDecl 记录一个匹配的结构体第一次出现的文件，生成的接口文件落在该文件对应的目标目录下
+type structDecl struct {
+	filePath string
+}
+
+// registryEntry 记录一个已生成接口对应的注册信息，供 --generateInitRegistry 按目标包
+// 汇总成 init() 注册文件；implPkgPath 直接取自 go/packages 解析出的真实导入路径
+type registryEntry struct {
+	targetDir       string
+	interfaceName   string
+	capitalizedName string
+	structName      string
+	implPkgPath     string
+}
+
+// processPackage 处理一个已加载的包：一个结构体可能分散在包内多个文件中实现
+// （例如 foo_dao.go + foo_dao_query.go），因此先汇总包内所有文件的导入，
+// 再为每个匹配的结构体聚合所有文件中的方法，去重后按名称排序生成一份接口
+func processPackage(pkg *packages.Package, srcDir, dstDir string, structPattern *regexp.Regexp, registry *[]registryEntry) error {
+	imports, aliasOf := collectPackageImports(pkg)
+
+	// 生成的接口和源码并不在同一个包里：方法如果引用了同包内的其他类型（比如同一个
+	// 文件里声明的另一个结构体），必须显式 import 源包本身并带别名限定，否则生成的
+	// 文件里会出现没有导入、未定义的裸标识符。别名要避开已有导入用到的标识符
+	usedAliases := map[string]bool{}
+	for _, imp := range imports {
+		alias := imp.Name
+		if alias == "" {
+			parts := strings.Split(imp.Path, "/")
+			alias = parts[len(parts)-1]
+		}
+		usedAliases[alias] = true
+	}
+	selfAlias := uniqueImplAlias(pkg.PkgPath, usedAliases)
+	imports = append(imports, ImportInfo{Name: selfAlias, Path: pkg.PkgPath})
+
+	structDecls := map[string]structDecl{}
+	var structOrder []string
+
+	for _, file := range pkg.Syntax {
+		filePath := pkg.Fset.Position(file.Pos()).Filename
+
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+
+				// 确认是结构体
+				if _, isStruct := typeSpec.Type.(*ast.StructType); !isStruct {
+					continue
+				}
+
+				structName := typeSpec.Name.Name
+
+				// 检查结构体名称是否匹配模式
+				if !structPattern.MatchString(structName) {
+					continue
+				}
+
+				if _, exists := structDecls[structName]; !exists {
+					structDecls[structName] = structDecl{filePath: filePath}
+					structOrder = append(structOrder, structName)
+				}
+			}
+		}
+	}
+
+	for _, structName := range structOrder {
+		decl := structDecls[structName]
+
+		// 聚合结构体在包内所有文件中定义的方法，并记录真正用到的导入路径
+		methods, usedImportPaths := findStructMethodsInPackage(pkg, structName, aliasOf, selfAlias)
+		if len(methods) == 0 {
+			continue
+		}
+
+		// 确定使用的导入
+		usedImports := findUsedImports(imports, usedImportPaths)
+
+		// 创建接口信息
+		interfaceName := *interfacePrefix + strings.ToUpper(structName[:1]) + structName[1:]
+
+		// 生成首字母大写的结构体名称
+		capitalizedName := strings.ToUpper(structName[:1]) + structName[1:]
+
+		// 生成目标文件名：按结构体名称派生，而不是沿用源文件名——否则同一个源文件里
+		// 声明的多个匹配结构体会生成同名的接口文件，后写入的会悄悄覆盖先写入的
+		targetFileName := toSnakeCase(structName) + ".go"
+
+		// 提前算出目标目录，从而结合最近的 go.mod 得到接口包的真实导入路径，
+		// 供 moq/testify mock 生成接口实现断言时 import 使用
+		targetDir, err := targetDirFor(srcDir, dstDir, decl.filePath)
+		if err != nil {
+			return fmt.Errorf("计算目标目录失败: %v", err)
+		}
+
+		buildConstraints, err := extractBuildConstraints(decl.filePath)
+		if err != nil {
+			return fmt.Errorf("提取构建约束失败: %v", err)
+		}
+
+		structInfo := StructInfo{
+			Name:              structName,
+			CapitalizedName:   capitalizedName,
+			InterfaceName:     interfaceName,
+			Methods:           methods,
+			Imports:           imports,
+			PackageImportPath: packageImportPathFor(targetDir),
+			BuildConstraints:  buildConstraints,
+			UsedImports:       usedImports,
+			GenerateRegister:  *generateRegister,
+			GenerateMock:      *generateMock,
+			MockStyle:         *mockStyle,
+			MockPath:          *mockPath,
+			TargetFileName:    targetFileName,
+		}
+
+		// 生成接口文件
+		targetDir, err = generateInterfaceFile(structInfo, decl.filePath, srcDir, dstDir)
+		if err != nil {
+			return fmt.Errorf("生成接口文件失败: %v", err)
+		}
+
+		// moq/testify 风格直接渲染出 mock 源文件，不依赖外部 mockgen/moq 可执行程序；
+		// mockgen 风格仍沿用接口文件里的 go:generate 指令，此处无需额外处理
+		if structInfo.GenerateMock && structInfo.MockStyle != "mockgen" {
+			if err := generateMockFile(structInfo, targetDir); err != nil {
+				return fmt.Errorf("生成mock文件失败: %v", err)
+			}
+		}
+
+		if *generateInitRegistry && structInfo.GenerateRegister {
+			*registry = append(*registry, registryEntry{
+				targetDir:       targetDir,
+				interfaceName:   interfaceName,
+				capitalizedName: capitalizedName,
+				structName:      structName,
+				implPkgPath:     pkg.PkgPath,
+			})
+		}
+	}
+
+	return nil
+}
+
+// collectPackageImports 汇总包内所有文件的导入，按导入路径去重，
+// 并记录路径到别名的映射（同一路径在多个文件中的别名以先出现的为准）
+func collectPackageImports(pkg *packages.Package) ([]ImportInfo, map[string]string) {
+	seenPaths := map[string]bool{}
+	imports := []ImportInfo{}
+	aliasOf := map[string]string{}
+
+	for _, file := range pkg.Syntax {
+		for _, imp := range file.Imports {
+			var name string
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			path := strings.Trim(imp.Path.Value, "\"")
+
+			if !seenPaths[path] {
+				seenPaths[path] = true
+				imports = append(imports, ImportInfo{Name: name, Path: path})
+			}
+
+			if name != "_" {
+				if _, ok := aliasOf[path]; !ok {
+					aliasOf[path] = name
+				}
+			}
+		}
+	}
+
+	return imports, aliasOf
+}
+
+// funcDeclSource 记录一个方法声明所在的文件信息，供按位置反查 *ast.FuncDecl 使用
+type funcDeclSource struct {
+	decl *ast.FuncDecl
+	cmap ast.CommentMap
+}
+
+// findStructMethodsInPackage 收集指定结构体的全部方法，包括直接定义的方法和从匿名
+// 嵌入字段提升上来的方法（如 EmbedDao{ pingHelper } 里 pingHelper.Ping 提升到 EmbedDao 上）。
+// 用 types.NewMethodSet 枚举方法集而不是按接收者名字匹配 AST，才能正确处理方法提升；
+// 只收录声明在本包内的方法，从其他包的内嵌类型提升上来的方法不会出现在接口里；
+// 按方法名去重（同名方法以方法集给出的优先级为准），并按名称排序以保证生成结果确定
+func findStructMethodsInPackage(pkg *packages.Package, structName string, aliasOf map[string]string, selfAlias string) ([]MethodInfo, map[string]bool) {
+	usedImportPaths := make(map[string]bool)
+	qual := newUsageQualifier(pkg.PkgPath, aliasOf, selfAlias, usedImportPaths)
+
+	// 按方法声明的标识符位置建立索引，这样拿到 go/types 方法集里的 *types.Func 后，
+	// 可以直接定位到它对应的 *ast.FuncDecl，复用原有的注释/参数渲染逻辑
+	funcDeclByPos := map[token.Pos]funcDeclSource{}
+	for _, file := range pkg.Syntax {
+		cmap := ast.NewCommentMap(pkg.Fset, file, file.Comments)
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil {
+				continue
+			}
+			funcDeclByPos[funcDecl.Name.Pos()] = funcDeclSource{decl: funcDecl, cmap: cmap}
+		}
+	}
+
+	obj := pkg.Types.Scope().Lookup(structName)
+	if obj == nil {
+		return nil, usedImportPaths
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, usedImportPaths
+	}
+
+	mset := types.NewMethodSet(types.NewPointer(named))
+
+	seen := map[string]bool{}
+	var methods []MethodInfo
+
+	for i := 0; i < mset.Len(); i++ {
+		fn, ok := mset.At(i).Obj().(*types.Func)
+		if !ok {
+			continue
+		}
+
+		// 方法声明不在当前包的语法树里（例如从其他包的内嵌类型提升上来的方法，
+		// 如内嵌 sync.Mutex 得到的 Lock/Unlock），找不到对应的 *ast.FuncDecl，跳过不生成
+		src, ok := funcDeclByPos[fn.Pos()]
+		if !ok {
+			continue
+		}
+
+		methodName := fn.Name()
+		if seen[methodName] {
+			continue
+		}
+		seen[methodName] = true
+
+		funcDecl := src.decl
+		cmap := src.cmap
+
+		// 获取参数
+		params := formatFieldList(funcDecl.Type.Params, pkg.TypesInfo, qual, cmap)
+
+		// 获取返回值
+		results := formatFieldList(funcDecl.Type.Results, pkg.TypesInfo, qual, cmap)
+
+		// 获取参数名
+		paramNames := formatParamNames(funcDecl.Type.Params)
+
+		// 结构化的参数/返回值列表，供 moq/testify 风格的 mock 生成使用
+		paramList := extractParamList(funcDecl.Type.Params, pkg.TypesInfo, qual)
+		resultList := extractParamList(funcDecl.Type.Results, pkg.TypesInfo, qual)
+
+		// 原样渲染文档注释（多行 godoc、Deprecated 块、/* */ 块等），
+		// 不再用 Doc.Text() 把整段注释拼成一行
+		comment := renderDocComment(funcDecl.Doc)
+
+		methods = append(methods, MethodInfo{
+			Name:       methodName,
+			Params:     params,
+			Results:    results,
+			ParamNames: paramNames,
+			ParamList:  paramList,
+			ResultList: resultList,
+			Comment:    comment,
+		})
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	return methods, usedImportPaths
+}
+
+// newUsageQualifier 构造一个 types.Qualifier：渲染类型名时按导入别名（包括点导入）
+// 限定包名，并把实际用到的导入路径记录下来，替代过去依据标识符前缀的猜测方式。
+// 生成的接口文件和结构体的源码包并不是同一个包，所以 selfPath（结构体所在的源包）
+// 不能像内置类型那样省略限定——必须按 selfAlias 显式限定并 import，否则引用到的
+// 同包兄弟类型（比如同一个文件里声明的另一个结构体）会在生成文件里变成未定义标识符
+func newUsageQualifier(selfPath string, aliasOf map[string]string, selfAlias string, used map[string]bool) types.Qualifier {
+	return func(p *types.Package) string {
+		if p == nil {
+			return ""
+		}
+
+		if p.Path() == selfPath {
+			used[selfPath] = true
+			return selfAlias
+		}
+
+		used[p.Path()] = true
+
+		if alias, ok := aliasOf[p.Path()]; ok {
+			if alias == "." {
+				return ""
+			}
+			if alias != "" {
+				return alias
+			}
+		}
+
+		return p.Name()
+	}
+}
+
+// renderDocComment 原样渲染一段文档注释：每个 *ast.Comment 的 Text 本身就带着
+// "//" 或 "/* */" 定界符，逐行拼接即可保留多行 godoc、Deprecated 块、块注释等原始结构，
+// 不再像 CommentGroup.Text() 那样去掉定界符后拼成一整行
+func renderDocComment(doc *ast.CommentGroup) string {
+	if doc == nil || len(doc.List) == 0 {
+		return ""
+	}
+
+	lines := make([]string, len(doc.List))
+	for i, c := range doc.List {
+		lines[i] = c.Text
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// fieldTrailingComment 取出字段同一行尾部的注释（如 "id int64, // 主键"）。
+// go/parser 不会把函数参数列表里的行尾注释挂到 *ast.Field.Comment 上（只有结构体字段才会），
+// 所以这里从 findStructMethodsInPackage 按位置重新关联出的 CommentMap 里查找
+func fieldTrailingComment(cmap ast.CommentMap, field *ast.Field) string {
+	groups := cmap[field]
+	if len(groups) == 0 {
+		return ""
+	}
+
+	var texts []string
+	for _, g := range groups {
+		if text := strings.TrimSpace(g.Text()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	return strings.Join(texts, "; ")
+}
+
+// 格式化字段列表，类型名通过 go/types 解析得到，能够正确处理跨文件/跨包的命名类型。
+// 只要有任意一个字段带行尾注释，就把参数列表逐个换行排列，避免注释把后面的参数或右括号注释掉
+func formatFieldList(fieldList *ast.FieldList, info *types.Info, qual types.Qualifier, cmap ast.CommentMap) string {
+	if fieldList == nil || len(fieldList.List) == 0 {
+		return ""
+	}
+
+	type renderedField struct {
+		text    string
+		comment string
+	}
+
+	var fields []renderedField
+	hasComment := false
+
+	for _, field := range fieldList.List {
+		typeExpr := formatType(field.Type, info, qual)
+		comment := fieldTrailingComment(cmap, field)
+		if comment != "" {
+			hasComment = true
+		}
+
+		if len(field.Names) == 0 {
+			fields = append(fields, renderedField{text: typeExpr, comment: comment})
+			continue
+		}
+
+		for _, name := range field.Names {
+			fields = append(fields, renderedField{text: fmt.Sprintf("%s %s", name.Name, typeExpr), comment: comment})
+		}
+	}
+
+	if !hasComment {
+		texts := make([]string, len(fields))
+		for i, f := range fields {
+			texts[i] = f.text
+		}
+		return strings.Join(texts, ", ")
+	}
+
+	var buf strings.Builder
+	for _, f := range fields {
+		buf.WriteString("\n\t")
+		buf.WriteString(f.text)
+		buf.WriteString(",")
+		if f.comment != "" {
+			buf.WriteString(" // ")
+			buf.WriteString(f.comment)
+		}
+	}
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+// extractParamList 把字段列表解析为结构化的 (名称, 类型) 列表，供 mock 代码生成使用。
+// 未命名的参数（包括 "_"）会被赋予 argN 这样的占位名，保证每个 mock 方法都有变量可用
+func extractParamList(fieldList *ast.FieldList, info *types.Info, qual types.Qualifier) []Param {
+	if fieldList == nil || len(fieldList.List) == 0 {
+		return nil
+	}
+
+	var params []Param
+	argIndex := 0
+
+	for _, field := range fieldList.List {
+		typeExpr := formatType(field.Type, info, qual)
+		_, isVariadic := field.Type.(*ast.Ellipsis)
+
+		if len(field.Names) == 0 {
+			params = append(params, Param{Name: fmt.Sprintf("arg%d", argIndex), Type: typeExpr, IsVariadic: isVariadic})
+			argIndex++
+			continue
+		}
+
+		for _, name := range field.Names {
+			paramName := name.Name
+			if paramName == "_" {
+				paramName = fmt.Sprintf("arg%d", argIndex)
+			}
+			params = append(params, Param{Name: paramName, Type: typeExpr, IsVariadic: isVariadic})
+			argIndex++
+		}
+	}
+
+	return params
+}
+
+// 优先使用类型检查结果渲染类型名；仅当类型信息缺失时才回退到语法层面的打印
+func formatType(expr ast.Expr, info *types.Info, qual types.Qualifier) string {
+	// 可变参数（...T）的 go/types 类型是 []T，如果直接用 types.TypeString 渲染会丢掉
+	// "..."，生成的接口签名就和原方法不再一致，导致结构体不再实现这个接口
+	if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+		return "..." + formatType(ellipsis.Elt, info, qual)
+	}
+
+	if info != nil {
+		if t := info.TypeOf(expr); t != nil {
+			return types.TypeString(t, qual)
+		}
+	}
+	return formatExpr(expr)
+}
+
+// 查找接口中真正使用到的导入：usedImportPaths 由类型检查阶段精确记录，
+// 不再依据标识符前缀与包名做字符串匹配
+func findUsedImports(imports []ImportInfo, usedImportPaths map[string]bool) map[string]bool {
+	usedImports := make(map[string]bool)
+
+	for _, imp := range imports {
+		if usedImportPaths[imp.Path] {
+			usedImports[imp.Path] = true
+		}
+	}
+
+	return usedImports
+}
+
+// 格式化参数名
+func formatParamNames(fieldList *ast.FieldList) string {
+	if fieldList == nil || len(fieldList.List) == 0 {
+		return ""
+	}
+
+	var result []string
+	for _, field := range fieldList.List {
+		if len(field.Names) == 0 {
+			result = append(result, "_")
+		} else {
+			for _, name := range field.Names {
+				result = append(result, name.Name)
+			}
+		}
+	}
+
+	return strings.Join(result, ", ")
+}
+
+// 格式化表达式（在类型信息缺失时的兜底方案）
+func formatExpr(expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer := token.NewFileSet()
+	format.Node(&buf, printer, expr)
+	return buf.String()
+}
+
+// 生成接口文件，返回接口文件所在的目标目录，供调用方在需要时生成同目录下的 init 注册文件
+func generateInterfaceFile(info StructInfo, srcFilePath, srcDir, dstDir string) (string, error) {
+	targetDir, err := targetDirFor(srcDir, dstDir, srcFilePath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", err
+	}
+
+	// 使用目标目录名称作为包名
+	targetPackageName := filepath.Base(targetDir)
+
+	// 按结构体名称派生的文件名生成，保证同一个源文件里的多个结构体不会互相覆盖
+	targetFilePath := filepath.Join(targetDir, info.TargetFileName)
+
+	// 使用模板生成接口文件
+	tmpl, err := template.New("interface").Parse(`{{range .BuildConstraints}}{{.}}
+{{end}}{{if .BuildConstraints}}
+{{end}}package {{.TargetPackageName}}
+
+import (
+	{{range .Imports}}{{if .Used}}{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
+	{{end}}{{end}}
+)
+
+// {{.InterfaceName}} 是 {{.Name}} 的接口定义
+{{if and .GenerateMock (eq .MockStyle "mockgen")}}//go:generate mockgen -source={{.TargetFileName}} -destination={{.MockPath}}/{{.TargetFileName}} -package=mocks{{end}}
+type {{.InterfaceName}} interface {
+	{{range .Methods}}{{if .Comment}}{{.Comment}}
+	{{end}}{{.Name}}({{.Params}}) {{if .Results}}({{.Results}}){{end}}
+	{{end}}
+}
+
+{{if .GenerateRegister}}var (
+	local{{.InterfaceName}} {{.InterfaceName}}
+)
+
+func {{.CapitalizedName}}() {{.InterfaceName}} {
+	if local{{.InterfaceName}} == nil {
+		panic("implement not found for interface {{.InterfaceName}}, forgot register?")
+	}
+	return local{{.InterfaceName}}
+}
+
+func Register{{.CapitalizedName}}(i {{.InterfaceName}}) {
+	local{{.InterfaceName}} = i
+}{{end}}
+`)
+	if err != nil {
+		return "", err
+	}
+
+	// 准备模板数据
+	type TemplateImport struct {
+		Name string
+		Path string
+		Used bool
+	}
+
+	templateImports := []TemplateImport{}
+	for _, imp := range info.Imports {
+		used := info.UsedImports[imp.Path]
+		templateImports = append(templateImports, TemplateImport{
+			Name: imp.Name,
+			Path: imp.Path,
+			Used: used,
+		})
+	}
+
+	// 检查是否有使用的导入
+	hasImports := false
+	for _, imp := range templateImports {
+		if imp.Used {
+			hasImports = true
+			break
+		}
+	}
+
+	templateData := struct {
+		StructInfo
+		Imports           []TemplateImport
+		HasImports        bool
+		TargetPackageName string
+	}{
+		StructInfo:        info,
+		Imports:           templateImports,
+		HasImports:        hasImports,
+		TargetPackageName: targetPackageName, // 使用目标目录名称作为包名
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData); err != nil {
+		return "", err
+	}
+
+	// 格式化代码
+	formattedCode, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("格式化代码失败: %v\n%s", err, buf.String())
+	}
+
+	// 写入文件
+	if err := ioutil.WriteFile(targetFilePath, formattedCode, 0644); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("生成接口文件: %s\n", targetFilePath)
+	return targetDir, nil
+}
+
+// generateInitRegistryFiles 按目标目录对注册项分组，每个目标包生成一份 init 注册文件
+func generateInitRegistryFiles(registry []registryEntry) error {
+	byDir := map[string][]registryEntry{}
+	var dirOrder []string
+
+	for _, entry := range registry {
+		if _, ok := byDir[entry.targetDir]; !ok {
+			dirOrder = append(dirOrder, entry.targetDir)
+		}
+		byDir[entry.targetDir] = append(byDir[entry.targetDir], entry)
+	}
+	sort.Strings(dirOrder)
+
+	for _, dir := range dirOrder {
+		if err := generatePackageInitRegistry(dir, byDir[dir]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// implImportAlias 持有分配给某个实现包的短别名
+type implImportAlias struct {
+	Alias string
+	Path  string
+}
+
+// registryCall 对应模板中一次 RegisterXxx(&alias.StructName{}) 调用
+type registryCall struct {
+	Alias           string
+	StructName      string
+	CapitalizedName string
+}
+
+// generatePackageInitRegistry 为单个目标包生成 <包名>_init_registry.go：
+// 导入该包下所有接口对应的实现包（使用短别名避免冲突），并在 init() 中逐一调用 RegisterXxx，
+// 取代过去需要手工编写的 DI 注册胶水代码
+func generatePackageInitRegistry(targetDir string, entries []registryEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].capitalizedName < entries[j].capitalizedName
+	})
+
+	aliasOfPath := map[string]string{}
+	usedAliases := map[string]bool{}
+	var imports []implImportAlias
+
+	for _, entry := range entries {
+		if _, ok := aliasOfPath[entry.implPkgPath]; ok {
+			continue
+		}
+
+		alias := uniqueImplAlias(entry.implPkgPath, usedAliases)
+		usedAliases[alias] = true
+		aliasOfPath[entry.implPkgPath] = alias
+		imports = append(imports, implImportAlias{Alias: alias, Path: entry.implPkgPath})
+	}
+
+	var calls []registryCall
+	for _, entry := range entries {
+		calls = append(calls, registryCall{
+			Alias:           aliasOfPath[entry.implPkgPath],
+			StructName:      entry.structName,
+			CapitalizedName: entry.capitalizedName,
+		})
+	}
+
+	packageName := filepath.Base(targetDir)
+	targetFilePath := filepath.Join(targetDir, packageName+"_init_registry.go")
+
+	tmpl, err := template.New("init_registry").Parse(`package {{.PackageName}}
+
+import (
+	{{range .Imports}}{{.Alias}} "{{.Path}}"
+	{{end}}
+)
+
+// init 在包加载时自动完成本包下所有接口的默认实现注册，替代手写的 DI 注册胶水代码
+func init() {
+	{{range .Calls}}Register{{.CapitalizedName}}(&{{.Alias}}.{{.StructName}}{})
+	{{end}}
+}
+`)
+	if err != nil {
+		return err
+	}
+
+	templateData := struct {
+		PackageName string
+		Imports     []implImportAlias
+		Calls       []registryCall
+	}{
+		PackageName: packageName,
+		Imports:     imports,
+		Calls:       calls,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData); err != nil {
+		return err
+	}
+
+	formattedCode, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("格式化代码失败: %v\n%s", err, buf.String())
+	}
+
+	if err := ioutil.WriteFile(targetFilePath, formattedCode, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("生成 init 注册文件: %s\n", targetFilePath)
+	return nil
+}
+
+// uniqueImplAlias 基于导入路径的最后一段生成一个在 used 中尚未出现的短别名
+func uniqueImplAlias(pkgPath string, used map[string]bool) string {
+	parts := strings.Split(pkgPath, "/")
+	base := sanitizeIdent(parts[len(parts)-1])
+	if base == "" {
+		base = "impl"
+	}
+
+	alias := base
+	for i := 1; used[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+
+	return alias
+}
+
+// sanitizeIdent 把导入路径片段中不合法的标识符字符替换掉，使其可以作为包别名使用
+func sanitizeIdent(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		if r == '-' || r == '.' {
+			buf.WriteRune('_')
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// commonInitialisms 取自 golint 的常见缩写表，用于把参数名转换为符合 Go 习惯的导出标识符
+// （例如 id -> ID、userID -> UserID），避免生成的 mock 代码触发 lint 警告
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "QPS": true, "RAM": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// exportedFieldName 把一个参数名转换为导出字段名：按驼峰边界切分后，
+// 命中 commonInitialisms 的片段整体大写，其余片段首字母大写
+func exportedFieldName(name string) string {
+	if name == "" || name == "_" {
+		return "Arg"
+	}
+
+	var buf strings.Builder
+	for _, word := range splitCamelCase(name) {
+		upper := strings.ToUpper(word)
+		if commonInitialisms[upper] {
+			buf.WriteString(upper)
+			continue
+		}
+		buf.WriteString(strings.ToUpper(word[:1]))
+		buf.WriteString(word[1:])
+	}
+
+	return buf.String()
+}
+
+// splitCamelCase 按驼峰边界（小写到大写的过渡）切分标识符，例如 "userID" -> ["user", "ID"]
+func splitCamelCase(s string) []string {
+	var words []string
+	var cur []rune
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(runes[i-1]) {
+			words = append(words, string(cur))
+			cur = nil
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+
+	return words
+}
+
+// generateMockFile 根据 --mockStyle 渲染 mock 源文件本身，取代外部 mockgen/moq 可执行程序
+func generateMockFile(info StructInfo, targetDir string) error {
+	switch info.MockStyle {
+	case "moq":
+		return generateMoqMockFile(info, targetDir)
+	case "testify":
+		return generateTestifyMockFile(info, targetDir)
+	default:
+		return fmt.Errorf("不支持的 mockStyle: %s", info.MockStyle)
+	}
+}
+
+// moqRecordField 描述调用记录结构体中的一个字段：FieldName 是按 golint 缩写表大写后的
+// 导出字段名，ParamName 是方法签名里原本的参数变量名（用于在 callInfo 字面量中取值）
+type moqRecordField struct {
+	FieldName string
+	ParamName string
+	Type      string
+}
+
+// moqMethodData 是渲染 moq 风格 mock 时每个方法需要的数据
+type moqMethodData struct {
+	Name         string
+	FuncField    string
+	LockField    string
+	CallsField   string
+	ParamSig     string
+	ResultSig    string
+	HasResults   bool
+	CallArgs     string
+	RecordFields []moqRecordField
+}
+
+// buildMoqMethodData 把 MethodInfo 转换为 moq 模板所需的数据，字段名按 golint 缩写表大写。
+// 可变参数（...T）只在方法签名里保留 "..."：调用记录里按切片 []T 存一份完整参数，
+// 转发调用时则带上 "..." 展开，否则生成的代码要么类型不对，要么编译不通过
+func buildMoqMethodData(m MethodInfo) moqMethodData {
+	recordFields := make([]moqRecordField, len(m.ParamList))
+	callArgs := make([]string, len(m.ParamList))
+	// 方法签名按 ParamList 重新拼出，未命名或 "_" 参数用 argN 占位名，保证后面引用的变量都已声明
+	paramSig := make([]string, len(m.ParamList))
+	for i, p := range m.ParamList {
+		paramSig[i] = p.Name + " " + p.Type
+		recordType := p.Type
+		callArg := p.Name
+		if p.IsVariadic {
+			recordType = "[]" + strings.TrimPrefix(p.Type, "...")
+			callArg = p.Name + "..."
+		}
+		recordFields[i] = moqRecordField{FieldName: exportedFieldName(p.Name), ParamName: p.Name, Type: recordType}
+		callArgs[i] = callArg
+	}
+
+	return moqMethodData{
+		Name:         m.Name,
+		FuncField:    m.Name + "Func",
+		LockField:    "lock" + m.Name,
+		CallsField:   m.Name,
+		ParamSig:     strings.Join(paramSig, ", "),
+		ResultSig:    wrapResults(m.Results),
+		HasResults:   m.Results != "",
+		CallArgs:     strings.Join(callArgs, ", "),
+		RecordFields: recordFields,
+	}
+}
+
+// generateMoqMockFile 渲染一份 moq 风格的 mock 源文件：每个接口方法对应一个可替换的
+// Func 字段、一组带互斥锁保护的调用记录，以及在 Func 字段为 nil 时 panic 的转发方法
+func generateMoqMockFile(info StructInfo, targetDir string) error {
+	mockDir := filepath.Join(targetDir, info.MockPath)
+	if err := os.MkdirAll(mockDir, 0755); err != nil {
+		return err
+	}
+
+	mockName := info.CapitalizedName + "Mock"
+
+	var methods []moqMethodData
+	for _, m := range info.Methods {
+		methods = append(methods, buildMoqMethodData(m))
+	}
+
+	tmpl, err := template.New("moqMock").Parse(`package mocks
+
+import (
+	"sync"
+	{{range .Imports}}{{if .Used}}{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
+	{{end}}{{end}}
+	{{if .TargetPackageImportPath}}target "{{.TargetPackageImportPath}}"
+	{{end}}
+)
+
+{{if .TargetPackageImportPath}}var _ target.{{.InterfaceName}} = (*{{.MockName}})(nil)
+{{end}}
+// {{.MockName}} 是 {{.InterfaceName}} 的 moq 风格 mock 实现
+type {{.MockName}} struct {
+	{{range .Methods}}// {{.FuncField}} 在调用 {{.Name}} 时被转发执行
+	{{.FuncField}} func({{.ParamSig}}) {{.ResultSig}}
+	{{end}}
+	calls struct {
+		{{range .Methods}}{{.CallsField}} []struct {
+			{{range .RecordFields}}{{.FieldName}} {{.Type}}
+			{{end}}
+		}
+		{{end}}
+	}
+	{{range .Methods}}{{.LockField}} sync.RWMutex
+	{{end}}
+}
+
+{{range .Methods}}
+func (mock *{{$.MockName}}) {{.Name}}({{.ParamSig}}) {{.ResultSig}} {
+	if mock.{{.FuncField}} == nil {
+		panic("{{$.MockName}}.{{.FuncField}}: method is nil but {{$.MockName}}.{{.Name}} was just called")
+	}
+	callInfo := struct {
+		{{range .RecordFields}}{{.FieldName}} {{.Type}}
+		{{end}}
+	}{
+		{{range .RecordFields}}{{.FieldName}}: {{.ParamName}},
+		{{end}}
+	}
+	mock.{{.LockField}}.Lock()
+	mock.calls.{{.CallsField}} = append(mock.calls.{{.CallsField}}, callInfo)
+	mock.{{.LockField}}.Unlock()
+	{{if .HasResults}}return mock.{{.FuncField}}({{.CallArgs}})
+	{{else}}mock.{{.FuncField}}({{.CallArgs}})
+	{{end}}
+}
+
+// {{.Name}}Calls 返回 {{.Name}} 方法到目前为止被记录下来的全部调用参数
+func (mock *{{$.MockName}}) {{.Name}}Calls() []struct {
+	{{range .RecordFields}}{{.FieldName}} {{.Type}}
+	{{end}}
+} {
+	var calls []struct {
+		{{range .RecordFields}}{{.FieldName}} {{.Type}}
+		{{end}}
+	}
+	mock.{{.LockField}}.RLock()
+	calls = mock.calls.{{.CallsField}}
+	mock.{{.LockField}}.RUnlock()
+	return calls
+}
+{{end}}
+`)
+	if err != nil {
+		return err
+	}
+
+	type templateImport struct {
+		Name string
+		Path string
+		Used bool
+	}
+
+	var imports []templateImport
+	for _, imp := range info.Imports {
+		imports = append(imports, templateImport{Name: imp.Name, Path: imp.Path, Used: info.UsedImports[imp.Path]})
+	}
+
+	templateData := struct {
+		MockName                string
+		InterfaceName           string
+		Imports                 []templateImport
+		Methods                 []moqMethodData
+		TargetPackageImportPath string
+	}{
+		MockName:                mockName,
+		InterfaceName:           info.InterfaceName,
+		Imports:                 imports,
+		Methods:                 methods,
+		TargetPackageImportPath: info.PackageImportPath,
+	}
+
+	return renderAndWriteMockFile(tmpl, templateData, filepath.Join(mockDir, info.TargetFileName))
+}
+
+// testifyMethodData 是渲染 testify/mock 风格 mock 时每个方法需要的数据
+type testifyMethodData struct {
+	Name       string
+	ParamSig   string
+	ResultSig  string
+	HasResults bool
+	CallArgs   string
+	ReturnExpr string // 当有返回值时，如何从 m.Called(...) 的结果中取出各返回值
+}
+
+// buildTestifyMethodData 把 MethodInfo 转换为 testify 模板所需的数据；
+// 错误类型的返回值使用 args.Error(i)，其余类型用 args.Get(i).(Type) 做类型断言
+func buildTestifyMethodData(m MethodInfo) testifyMethodData {
+	callArgs := make([]string, len(m.ParamList))
+	// 方法签名按 ParamList 重新拼出，未命名或 "_" 参数用 argN 占位名，保证 m.Called 引用的变量都已声明
+	paramSig := make([]string, len(m.ParamList))
+	for i, p := range m.ParamList {
+		paramSig[i] = p.Name + " " + p.Type
+		callArgs[i] = p.Name
+	}
+
+	var returnExprs []string
+	for i, r := range m.ResultList {
+		if r.Type == "error" {
+			returnExprs = append(returnExprs, fmt.Sprintf("args.Error(%d)", i))
+			continue
+		}
+		returnExprs = append(returnExprs, fmt.Sprintf("args.Get(%d).(%s)", i, r.Type))
+	}
+
+	return testifyMethodData{
+		Name:       m.Name,
+		ParamSig:   strings.Join(paramSig, ", "),
+		ResultSig:  wrapResults(m.Results),
+		HasResults: m.Results != "",
+		CallArgs:   strings.Join(callArgs, ", "),
+		ReturnExpr: strings.Join(returnExprs, ", "),
+	}
+}
+
+// wrapResults 把逗号分隔的返回值类型列表包上括号，和接口方法签名里的写法保持一致；
+// 没有返回值时原样返回空字符串
+func wrapResults(results string) string {
+	if results == "" {
+		return ""
+	}
+	return "(" + results + ")"
+}
+
+// generateTestifyMockFile 渲染一份基于 github.com/stretchr/testify/mock 的 mock 源文件：
+// 每个方法把调用转发给 m.Called(...)，再从返回的 mock.Arguments 里按类型取出各返回值
+func generateTestifyMockFile(info StructInfo, targetDir string) error {
+	mockDir := filepath.Join(targetDir, info.MockPath)
+	if err := os.MkdirAll(mockDir, 0755); err != nil {
+		return err
+	}
+
+	mockName := info.CapitalizedName + "Mock"
+
+	var methods []testifyMethodData
+	for _, m := range info.Methods {
+		methods = append(methods, buildTestifyMethodData(m))
+	}
+
+	tmpl, err := template.New("testifyMock").Parse(`package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+	{{range .Imports}}{{if .Used}}{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
+	{{end}}{{end}}
+	{{if .TargetPackageImportPath}}target "{{.TargetPackageImportPath}}"
+	{{end}}
+)
+
+{{if .TargetPackageImportPath}}var _ target.{{.InterfaceName}} = (*{{.MockName}})(nil)
+{{end}}
+// {{.MockName}} 是 {{.InterfaceName}} 基于 testify/mock 的 mock 实现
+type {{.MockName}} struct {
+	mock.Mock
+}
+
+{{range .Methods}}
+func (m *{{$.MockName}}) {{.Name}}({{.ParamSig}}) {{.ResultSig}} {
+	args := m.Called({{.CallArgs}})
+	{{if .HasResults}}return {{.ReturnExpr}}
+	{{end}}
+}
+{{end}}
+`)
+	if err != nil {
+		return err
+	}
+
+	type templateImport struct {
+		Name string
+		Path string
+		Used bool
+	}
+
+	var imports []templateImport
+	for _, imp := range info.Imports {
+		imports = append(imports, templateImport{Name: imp.Name, Path: imp.Path, Used: info.UsedImports[imp.Path]})
+	}
+
+	templateData := struct {
+		MockName                string
+		InterfaceName           string
+		Imports                 []templateImport
+		Methods                 []testifyMethodData
+		TargetPackageImportPath string
+	}{
+		MockName:                mockName,
+		InterfaceName:           info.InterfaceName,
+		Imports:                 imports,
+		Methods:                 methods,
+		TargetPackageImportPath: info.PackageImportPath,
+	}
+
+	return renderAndWriteMockFile(tmpl, templateData, filepath.Join(mockDir, info.TargetFileName))
+}
+
+// renderAndWriteMockFile 执行模板、用 gofmt 规则格式化代码，并写入目标文件
+func renderAndWriteMockFile(tmpl *template.Template, data interface{}, targetFilePath string) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	formattedCode, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("格式化mock代码失败: %v\n%s", err, buf.String())
+	}
+
+	if err := ioutil.WriteFile(targetFilePath, formattedCode, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("生成mock文件: %s\n", targetFilePath)
+	return nil
+}
+
+// 将大驼峰命名转换为蛇形命名
+func toSnakeCase(s string) string {
+	var result string
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			result += "_"
+		}
+		result += strings.ToLower(string(r))
+	}
+	return result
+}
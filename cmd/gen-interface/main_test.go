@@ -0,0 +1,420 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// TestProcessDirectory_AggregatesMethodsAcrossFiles 验证当同一个结构体的方法分散在
+// 包内多个文件中时（如 foo_dao.go + foo_dao_query.go），生成的接口会包含全部方法，
+// 且不会因为在两个文件中各被发现一次而重复出现。
+func TestProcessDirectory_AggregatesMethodsAcrossFiles(t *testing.T) {
+	srcDir := filepath.Join("testdata", "multifile")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, "foo_dao.go"))
+	if err != nil {
+		t.Fatalf("读取生成的接口文件失败: %v", err)
+	}
+
+	content := string(generated)
+	for _, method := range []string{"GetByID(", "ListByName(", "Count("} {
+		if !strings.Contains(content, method) {
+			t.Errorf("生成的接口缺少来自其他文件的方法 %s，内容:\n%s", method, content)
+		}
+	}
+
+	if n := strings.Count(content, "GetByID("); n != 1 {
+		t.Errorf("方法 GetByID 应当只出现一次，实际出现 %d 次，内容:\n%s", n, content)
+	}
+}
+
+// TestProcessDirectory_GenerateInitRegistry 验证开启 --generateInitRegistry 后，
+// 会在目标包下生成一份 init 注册文件，自动调用该包中所有接口的 RegisterXxx。
+func TestProcessDirectory_GenerateInitRegistry(t *testing.T) {
+	*generateRegister = true
+	*generateInitRegistry = true
+	defer func() {
+		*generateRegister = false
+		*generateInitRegistry = false
+	}()
+
+	srcDir := filepath.Join("testdata", "multifile")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	registryFile := filepath.Join(dstDir, "generated_init_registry.go")
+	content, err := os.ReadFile(registryFile)
+	if err != nil {
+		t.Fatalf("读取 init 注册文件失败: %v", err)
+	}
+
+	if !strings.Contains(string(content), "func init()") {
+		t.Errorf("init 注册文件缺少 init() 函数，内容:\n%s", content)
+	}
+	if !strings.Contains(string(content), "RegisterFooDao(&") {
+		t.Errorf("init 注册文件缺少 RegisterFooDao 调用，内容:\n%s", content)
+	}
+}
+
+// TestProcessDirectory_GenerateMoqMock 验证 --mockStyle=moq 会直接渲染出 mock 源文件
+// （包含 Func 字段、转发方法和 Calls 访问器），而不是像 mockgen 模式那样只留一行 go:generate 指令。
+func TestProcessDirectory_GenerateMoqMock(t *testing.T) {
+	*generateMock = true
+	*mockStyle = "moq"
+	*mockPath = "mocks"
+	defer func() {
+		*generateMock = false
+		*mockStyle = "mockgen"
+		*mockPath = "../mocks"
+	}()
+
+	srcDir := filepath.Join("testdata", "multifile")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	mockFile := filepath.Join(dstDir, "mocks", "foo_dao.go")
+	content, err := os.ReadFile(mockFile)
+	if err != nil {
+		t.Fatalf("读取 mock 文件失败: %v", err)
+	}
+
+	got := string(content)
+	for _, want := range []string{
+		"type FooDaoMock struct",
+		"GetByIDFunc func(",
+		"func (mock *FooDaoMock) GetByID(",
+		"func (mock *FooDaoMock) GetByIDCalls() []struct",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("moq mock 缺少 %q，内容:\n%s", want, got)
+		}
+	}
+}
+
+// TestProcessDirectory_GitignoreExclude 验证源目录根部的 .gitignore 会和 --exclude
+// 合并生效：被 .gitignore 排除的目录不会生成接口，未被排除的包照常处理。
+func TestProcessDirectory_GitignoreExclude(t *testing.T) {
+	srcDir := filepath.Join("testdata", "gitignore")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "bar_dao.go")); err != nil {
+		t.Errorf("未被 .gitignore 排除的 bar_dao.go 应当被生成: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "skip")); err == nil {
+		t.Errorf("skip/ 目录已被 .gitignore 排除，不应当出现在生成结果中")
+	}
+}
+
+// TestProcessDirectory_GitignoreExcludeMatchesPathSegments 验证 .gitignore 规则按路径
+// 分段匹配目录，而不是裸字符串前缀：规则 "bin" 应当排除 bin/，但不能误伤名字恰好
+// 以 "bin" 开头的兄弟目录 bindings/。
+func TestProcessDirectory_GitignoreExcludeMatchesPathSegments(t *testing.T) {
+	srcDir := filepath.Join("testdata", "gitignore_boundary")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "bin")); err == nil {
+		t.Errorf("bin/ 目录已被 .gitignore 排除，不应当出现在生成结果中")
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "bindings", "binding_dao.go")); err != nil {
+		t.Errorf("bindings/ 只是名字前缀和 .gitignore 规则相同，不应当被排除: %v", err)
+	}
+}
+
+// TestProcessDirectory_ModuleAwareMockImport 验证生成 moq mock 时，会结合目标目录
+// 最近的 go.mod 推导出接口包的真实导入路径，从而能够 import 目标包并生成接口实现断言。
+func TestProcessDirectory_ModuleAwareMockImport(t *testing.T) {
+	*generateMock = true
+	*mockStyle = "moq"
+	*mockPath = "mocks"
+	defer func() {
+		*generateMock = false
+		*mockStyle = "mockgen"
+		*mockPath = "../mocks"
+	}()
+
+	goModData, err := os.ReadFile(filepath.Join("testdata", "withmod", "go.mod"))
+	if err != nil {
+		t.Fatalf("读取 go.mod 测试夹具失败: %v", err)
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), goModData, 0644); err != nil {
+		t.Fatalf("写入 go.mod 失败: %v", err)
+	}
+
+	srcDir := filepath.Join("testdata", "withmod")
+	dstDir := filepath.Join(root, "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, "mocks", "baz_dao.go"))
+	if err != nil {
+		t.Fatalf("读取 mock 文件失败: %v", err)
+	}
+
+	got := string(content)
+	if !strings.Contains(got, `target "fixture.example/withmod/generated"`) {
+		t.Errorf("mock 文件应当 import 基于 go.mod 推导出的目标包导入路径，内容:\n%s", got)
+	}
+	if !strings.Contains(got, "var _ target.IBazDao = (*BazDaoMock)(nil)") {
+		t.Errorf("mock 文件缺少接口实现断言，内容:\n%s", got)
+	}
+}
+
+// TestProcessDirectory_PreservesDocCommentsAndBuildTags 验证生成的接口会原样保留
+// 源文件的构建约束、方法的多行文档注释（含 Deprecated 块），以及参数的行尾注释。
+func TestProcessDirectory_PreservesDocCommentsAndBuildTags(t *testing.T) {
+	srcDir := filepath.Join("testdata", "docs")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, "doc_dao.go"))
+	if err != nil {
+		t.Fatalf("读取生成的接口文件失败: %v", err)
+	}
+
+	got := string(content)
+	for _, want := range []string{
+		"//go:build !skipthisfile",
+		"// Get 按主键查询一条记录。",
+		"// Deprecated: 请改用 GetByID。",
+		"// 主键",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("生成的接口缺少 %q，内容:\n%s", want, got)
+		}
+	}
+}
+
+// TestProcessDirectory_MultipleStructsInOneFile 验证同一个源文件里声明的多个匹配
+// 结构体（如 multi_dao.go 里的 AlphaDao 和 BetaDao）会各自生成独立的接口文件，
+// 文件名按结构体名称派生，不会因为都来自同一个源文件而互相覆盖。
+func TestProcessDirectory_MultipleStructsInOneFile(t *testing.T) {
+	srcDir := filepath.Join("testdata", "multistruct")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	alpha, err := os.ReadFile(filepath.Join(dstDir, "alpha_dao.go"))
+	if err != nil {
+		t.Fatalf("读取 AlphaDao 生成的接口文件失败: %v", err)
+	}
+	if !strings.Contains(string(alpha), "IAlphaDao interface") {
+		t.Errorf("alpha_dao.go 应当包含 IAlphaDao，内容:\n%s", alpha)
+	}
+
+	beta, err := os.ReadFile(filepath.Join(dstDir, "beta_dao.go"))
+	if err != nil {
+		t.Fatalf("读取 BetaDao 生成的接口文件失败: %v", err)
+	}
+	if !strings.Contains(string(beta), "IBetaDao interface") {
+		t.Errorf("beta_dao.go 应当包含 IBetaDao，内容:\n%s", beta)
+	}
+}
+
+// TestProcessDirectory_PromotesEmbeddedMethods 验证通过匿名字段嵌入的方法（如
+// EmbedDao{ *pingHelper } 里 pingHelper.Ping）会被提升到生成的接口里，而不是因为
+// 方法的接收者名字不是 EmbedDao 就被漏掉。
+func TestProcessDirectory_PromotesEmbeddedMethods(t *testing.T) {
+	srcDir := filepath.Join("testdata", "embed")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, "embed_dao.go"))
+	if err != nil {
+		t.Fatalf("读取生成的接口文件失败: %v", err)
+	}
+
+	content := string(generated)
+	for _, method := range []string{"Get(", "Ping("} {
+		if !strings.Contains(content, method) {
+			t.Errorf("生成的接口缺少方法 %s，内容:\n%s", method, content)
+		}
+	}
+}
+
+// TestProcessDirectory_VariadicMethodSignature 验证可变参数方法（ids ...int64）生成的
+// 接口签名保留 "..."，而不是退化成 go/types 眼中的底层切片类型 []int64——否则源结构体
+// 就不再实现自己的接口。这里不只做字符串断言，还把生成的接口和原始结构体放进同一个
+// 临时包里，用 go/packages 做一次真正的类型检查，确认 var _ IVarDao = (*VarDao)(nil) 成立。
+func TestProcessDirectory_VariadicMethodSignature(t *testing.T) {
+	goModData, err := os.ReadFile(filepath.Join("testdata", "variadic", "go.mod"))
+	if err != nil {
+		t.Fatalf("读取 go.mod 测试夹具失败: %v", err)
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), goModData, 0644); err != nil {
+		t.Fatalf("写入 go.mod 失败: %v", err)
+	}
+
+	srcDir := filepath.Join("testdata", "variadic")
+	dstDir := filepath.Join(root, "variadic")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, "var_dao.go"))
+	if err != nil {
+		t.Fatalf("读取生成的接口文件失败: %v", err)
+	}
+
+	got := string(generated)
+	if !strings.Contains(got, "ids ...int64") {
+		t.Errorf("可变参数应当渲染为 ...int64，而不是 []int64，内容:\n%s", got)
+	}
+	if strings.Contains(got, "[]int64") {
+		t.Errorf("可变参数不应当退化成 []int64，内容:\n%s", got)
+	}
+
+	// 把原始结构体的源码和生成的接口放进同一个包目录，再加一行实现断言，
+	// 用 go/packages 实际类型检查一遍——这是唯一能真正验证"结构体仍然实现接口"的办法
+	implSrc, err := os.ReadFile(filepath.Join(srcDir, "var_dao.go"))
+	if err != nil {
+		t.Fatalf("读取原始结构体源码失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dstDir, "impl.go"), implSrc, 0644); err != nil {
+		t.Fatalf("写入实现源码失败: %v", err)
+	}
+	assertion := "package variadic\n\nvar _ IVarDao = (*VarDao)(nil)\n"
+	if err := os.WriteFile(filepath.Join(dstDir, "assert.go"), []byte(assertion), 0644); err != nil {
+		t.Fatalf("写入接口实现断言失败: %v", err)
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir:  dstDir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		t.Fatalf("加载生成结果失败: %v", err)
+	}
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			t.Errorf("VarDao 未实现 IVarDao（或生成代码存在类型错误）: %v", pkgErr)
+		}
+	}
+}
+
+// TestProcessDirectory_SkipsBrokenPackageButKeepsOthers 验证某个包因为无法解析的
+// 导入而加载/类型检查失败时，processDirectory 不会因此中止整次运行——同一批目录里
+// 其他能正常处理的包照常生成。
+func TestProcessDirectory_SkipsBrokenPackageButKeepsOthers(t *testing.T) {
+	srcDir := filepath.Join("testdata", "partial")
+	dstDir := filepath.Join(t.TempDir(), "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 不应当因为 broken 包失败而返回错误: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "good", "good_dao.go")); err != nil {
+		t.Errorf("good 包不应当因为 broken 包加载失败而被一并跳过: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "broken", "broken_dao.go")); err == nil {
+		t.Errorf("broken 包的导入无法解析，不应当生成接口文件")
+	}
+}
+
+// TestProcessDirectory_QualifiesSiblingSourceType 验证方法签名里引用的同包兄弟类型
+// （如 FooDao.Get() *Bar，Bar 和 FooDao 声明在同一个源文件里）会被正确 import 并
+// 限定，而不是被当成和生成文件同包的类型而省略限定——生成的接口文件和源码并不在
+// 同一个包里。用本地 replace 把生成结果和源码接到同一个可编译的 module 里，
+// 真正跑一遍类型检查，而不只是做字符串匹配。
+func TestProcessDirectory_QualifiesSiblingSourceType(t *testing.T) {
+	srcDir := filepath.Join("testdata", "sibling")
+	srcAbs, err := filepath.Abs(srcDir)
+	if err != nil {
+		t.Fatalf("计算源目录绝对路径失败: %v", err)
+	}
+
+	root := t.TempDir()
+	goMod := "module fixture.example/generated\n\ngo 1.21\n\n" +
+		"require fixture.example/sibling v0.0.0\n\n" +
+		"replace fixture.example/sibling => " + srcAbs + "\n"
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatalf("写入 go.mod 失败: %v", err)
+	}
+
+	dstDir := filepath.Join(root, "generated")
+
+	pattern := regexp.MustCompile("^.+Dao$")
+	if err := processDirectory(srcDir, dstDir, nil, pattern); err != nil {
+		t.Fatalf("processDirectory 执行失败: %v", err)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, "foo_dao.go"))
+	if err != nil {
+		t.Fatalf("读取生成的接口文件失败: %v", err)
+	}
+
+	got := string(generated)
+	if !strings.Contains(got, `"fixture.example/sibling"`) {
+		t.Errorf("生成的接口应当 import 结构体所在的源码包，内容:\n%s", got)
+	}
+	if !strings.Contains(got, ".Bar") {
+		t.Errorf("同包兄弟类型 Bar 应当被限定引用，而不是裸写 *Bar，内容:\n%s", got)
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir:  dstDir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		t.Fatalf("加载生成结果失败: %v", err)
+	}
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			t.Errorf("生成的接口存在类型错误: %v", pkgErr)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !skipthisfile
+
+package docs
+
+import "context"
+
+// DocDao 用于验证构建约束、多行文档注释和参数尾注释会原样保留到生成的接口中
+type DocDao struct{}
+
+// Get 按主键查询一条记录。
+//
+// Deprecated: 请改用 GetByID。
+func (d *DocDao) Get(
+	ctx context.Context,
+	id int64, // 主键
+) (string, error) {
+	return "", nil
+}
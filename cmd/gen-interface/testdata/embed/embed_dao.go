@@ -0,0 +1,21 @@
+package embed
+
+import "context"
+
+// pingHelper 是一个被匿名嵌入的辅助类型，它的方法会被提升到嵌入它的结构体上
+type pingHelper struct{}
+
+// Ping 检查连接是否存活
+func (h *pingHelper) Ping(ctx context.Context) error {
+	return nil
+}
+
+// EmbedDao 用于验证匿名嵌入字段的方法会被提升到生成的接口里
+type EmbedDao struct {
+	*pingHelper
+}
+
+// Get 按主键查询
+func (d *EmbedDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}
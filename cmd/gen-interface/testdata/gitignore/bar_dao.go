@@ -0,0 +1,11 @@
+package gitignore
+
+import "context"
+
+// BarDao 用于验证源目录根部的 .gitignore 规则生效时，未被排除的包仍会被正常处理
+type BarDao struct{}
+
+// Get 按主键查询
+func (d *BarDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}
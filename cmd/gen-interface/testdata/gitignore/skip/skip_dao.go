@@ -0,0 +1,11 @@
+package skip
+
+import "context"
+
+// SkipDao 位于 .gitignore 排除的 skip/ 目录下，不应当出现在生成结果中
+type SkipDao struct{}
+
+// Get 按主键查询
+func (d *SkipDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}
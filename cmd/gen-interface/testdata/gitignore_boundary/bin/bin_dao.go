@@ -0,0 +1,8 @@
+package bin
+
+// BinDao 位于 .gitignore 排除的 bin/ 目录下，不应当被生成
+type BinDao struct{}
+
+func (d *BinDao) Get() string {
+	return ""
+}
@@ -0,0 +1,9 @@
+package bindings
+
+// BindingDao 所在的 bindings/ 目录名字只是恰好以 .gitignore 里的 "bin" 开头，
+// 不应当被 "bin" 这条规则误伤排除
+type BindingDao struct{}
+
+func (d *BindingDao) Get() string {
+	return ""
+}
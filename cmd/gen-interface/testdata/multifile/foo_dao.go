@@ -0,0 +1,11 @@
+package multifile
+
+import "context"
+
+// FooDao 用于测试方法跨文件聚合：其余方法定义在 foo_dao_query.go 中
+type FooDao struct{}
+
+// GetByID 根据主键查询一条记录
+func (d *FooDao) GetByID(ctx context.Context, id int64) (*Foo, error) {
+	return nil, nil
+}
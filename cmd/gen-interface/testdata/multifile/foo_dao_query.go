@@ -0,0 +1,19 @@
+package multifile
+
+import "context"
+
+// Foo 是 FooDao 操作的实体
+type Foo struct {
+	ID   int64
+	Name string
+}
+
+// ListByName 按名称查询记录列表
+func (d *FooDao) ListByName(ctx context.Context, name string) ([]*Foo, error) {
+	return nil, nil
+}
+
+// Count 统计记录总数
+func (d *FooDao) Count(ctx context.Context) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,19 @@
+package multistruct
+
+import "context"
+
+// AlphaDao 用于验证同一个源文件里的多个结构体会各自生成独立的接口文件
+type AlphaDao struct{}
+
+// Get 按主键查询
+func (d *AlphaDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}
+
+// BetaDao 和 AlphaDao 声明在同一个文件里，二者的接口文件不应互相覆盖
+type BetaDao struct{}
+
+// Get 按主键查询
+func (d *BetaDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,11 @@
+package broken
+
+import "doesnotexist"
+
+// BrokenDao 用于验证某个包因为无法解析的导入而加载/类型检查失败时，
+// 不会影响同一次运行里其他包的生成结果
+type BrokenDao struct{}
+
+func (d *BrokenDao) Get() doesnotexist.Thing {
+	return doesnotexist.Thing{}
+}
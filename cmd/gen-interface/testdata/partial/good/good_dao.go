@@ -0,0 +1,11 @@
+package good
+
+import "context"
+
+// GoodDao 用于验证它所在的包不会因为邻近目录 broken 加载失败而被一并跳过
+type GoodDao struct{}
+
+// Get 按主键查询
+func (d *GoodDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}
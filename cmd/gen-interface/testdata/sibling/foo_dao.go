@@ -0,0 +1,15 @@
+package sibling
+
+// Bar 是和 FooDao 声明在同一个源码包里的普通类型，用于验证生成的接口会正确
+// 导入并限定这类"同包兄弟类型"，而不是当作内置类型一样省略限定
+type Bar struct {
+	Name string
+}
+
+// FooDao 用于验证方法签名里引用的同包类型会被正确限定
+type FooDao struct{}
+
+// Get 返回一个同包声明的 *Bar
+func (d *FooDao) Get() *Bar {
+	return nil
+}
@@ -0,0 +1,11 @@
+package variadic
+
+import "context"
+
+// VarDao 用于验证可变参数方法能够正确生成 ...T 签名，而不是退化成 []T
+type VarDao struct{}
+
+// BatchGet 按一组主键批量查询
+func (d *VarDao) BatchGet(ctx context.Context, ids ...int64) ([]string, error) {
+	return nil, nil
+}
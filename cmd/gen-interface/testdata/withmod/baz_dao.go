@@ -0,0 +1,11 @@
+package withmod
+
+import "context"
+
+// BazDao 用于验证 mock 文件会基于最近的 go.mod 推导出接口包的真实导入路径
+type BazDao struct{}
+
+// Get 按主键查询
+func (d *BazDao) Get(ctx context.Context, id int64) (string, error) {
+	return "", nil
+}